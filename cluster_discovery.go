@@ -14,12 +14,21 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+// kubeClient bundles a clientset with the rest.Config used to build it, since
+// some operations (e.g. SPDY port-forwarding) need the raw config and can't
+// be done through the clientset alone.
+type kubeClient struct {
+	kc         *kubernetes.Clientset
+	restConfig *rest.Config
+}
+
 // discoverKubeconfigs fetches the clusters/contexts the user has configured and returns
 // a map from context name to a ready-to-use kubernetes clientset.
-func discoverKubeconfigs() (map[string]*kubernetes.Clientset, error) {
+func discoverKubeconfigs() (map[string]kubeClient, error) {
 	// Find the default kubeconfig path
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -33,7 +42,7 @@ func discoverKubeconfigs() (map[string]*kubernetes.Clientset, error) {
 		return nil, fmt.Errorf("could not load kubeconfig from %s: %w", kubeconfigPath, err)
 	}
 
-	clientsets := make(map[string]*kubernetes.Clientset)
+	clients := make(map[string]kubeClient)
 
 	// Iterate over all the contexts in the kubeconfig
 	for contextName := range config.Contexts {
@@ -49,15 +58,34 @@ func discoverKubeconfigs() (map[string]*kubernetes.Clientset, error) {
 		if err != nil {
 			return nil, fmt.Errorf("could not create clientset for context %s: %w", contextName, err)
 		}
-		clientsets[contextName] = clientset
+		clients[contextName] = kubeClient{kc: clientset, restConfig: restConfig}
 	}
 
-	return clientsets, nil
+	return clients, nil
 }
 
 type ClientEvent struct {
 	contextName string
 	kc          *kubernetes.Clientset
+	restConfig  *rest.Config
+}
+
+// ClusterSource discovers clusters multikuberay should watch for ray
+// clusters and emits ClientEvents as they come and go. kubeconfigSource (the
+// kubeconfig poller, backed by watchClients) and fleetSource (the
+// RayClusterFleet CRD controller) are both ClusterSources.
+type ClusterSource interface {
+	// Watch starts the source and returns channels of clusters added to and
+	// removed from it. Both channels are closed once ctx is cancelled.
+	Watch(ctx context.Context) (added <-chan ClientEvent, removed <-chan ClientEvent)
+}
+
+// kubeconfigSource is the original ClusterSource: it polls the user's
+// kubeconfig file on a timer and diffs the contexts it finds.
+type kubeconfigSource struct{}
+
+func (kubeconfigSource) Watch(ctx context.Context) (<-chan ClientEvent, <-chan ClientEvent) {
+	return watchClients(ctx)
 }
 
 func watchClients(ctx context.Context) (AddedChan <-chan ClientEvent, DeletedChan <-chan ClientEvent) {
@@ -75,8 +103,8 @@ func watchClients(ctx context.Context) (AddedChan <-chan ClientEvent, DeletedCha
 		if err != nil {
 			log.Printf("Failed initial list of kube configs: %v", err)
 		}
-		for name, kc := range clients {
-			added <- ClientEvent{contextName: name, kc: kc}
+		for name, client := range clients {
+			added <- ClientEvent{contextName: name, kc: client.kc, restConfig: client.restConfig}
 		}
 
 		// Loop and send added or removed contexts.
@@ -88,16 +116,16 @@ func watchClients(ctx context.Context) (AddedChan <-chan ClientEvent, DeletedCha
 				if err != nil {
 					log.Printf("Failed to find kube configs: %v", err)
 				}
-				for name, kc := range clients {
+				for name, client := range clients {
 					if _, ok := prevClients[name]; !ok {
 						// New client, not in prev clients.
-						added <- ClientEvent{contextName: name, kc: kc}
+						added <- ClientEvent{contextName: name, kc: client.kc, restConfig: client.restConfig}
 					}
 				}
-				for name, kc := range prevClients {
+				for name, client := range prevClients {
 					if _, ok := clients[name]; !ok {
 						// Client removed, in old but not new.
-						deleted <- ClientEvent{contextName: name, kc: kc}
+						deleted <- ClientEvent{contextName: name, kc: client.kc, restConfig: client.restConfig}
 					}
 				}
 				prevClients = clients
@@ -109,7 +137,7 @@ func watchClients(ctx context.Context) (AddedChan <-chan ClientEvent, DeletedCha
 	return added, deleted
 }
 
-func watchRayClusters(ctx context.Context, clusterContext string, kc *kubernetes.Clientset, indexer *ClusterIndexer, labelSelector string, filter func(*v1.Service) bool) {
+func watchRayClusters(ctx context.Context, clusterContext string, kc *kubernetes.Clientset, restConfig *rest.Config, indexer *ClusterIndexer, labelSelector string, filter func(*v1.Service) bool) {
 	var initialList *corev1.ServiceList
 	for {
 		var err error
@@ -131,7 +159,7 @@ func watchRayClusters(ctx context.Context, clusterContext string, kc *kubernetes
 			continue
 		}
 		log.Printf("Discovered %s from %s", service.GetName(), clusterContext)
-		indexer.Insert(ctx, makeHandle(clusterContext, kc, service))
+		indexer.Insert(ctx, makeHandle(clusterContext, kc, restConfig, service))
 	}
 
 	for ctx.Err() == nil {
@@ -161,7 +189,7 @@ func watchRayClusters(ctx context.Context, clusterContext string, kc *kubernetes
 
 			switch event.Type {
 			case watch.Added:
-				indexer.Insert(ctx, makeHandle(clusterContext, kc, *service))
+				indexer.Insert(ctx, makeHandle(clusterContext, kc, restConfig, *service))
 			case watch.Deleted:
 				indexer.Delete(clusterContext, string(service.UID))
 			default:
@@ -173,18 +201,27 @@ func watchRayClusters(ctx context.Context, clusterContext string, kc *kubernetes
 	}
 }
 
-func WatchAllContexts(ctx context.Context, indexer *ClusterIndexer) {
-	clientsAdded, clientsDeleted := watchClients(ctx)
+// WatchAllContexts fans the ClientEvents from every given source into a
+// single loop that starts (and stops) a watchRayClusters goroutine pair per
+// discovered cluster.
+func WatchAllContexts(ctx context.Context, indexer *ClusterIndexer, sources ...ClusterSource) {
+	clientsAdded, clientsDeleted := fanInSources(ctx, sources)
 	watchClusterStopFns := make(map[string]func())
 	for {
 		select {
 		case ev := <-clientsAdded:
 			log.Printf("Discovered kube context %s", ev.contextName)
+			if cancel, ok := watchClusterStopFns[ev.contextName]; ok {
+				// Already watching this context; stop the old watchers
+				// before starting a new pair so re-registering a source
+				// (e.g. a RayClusterFleet re-reconciling) doesn't leak them.
+				cancel()
+			}
 			watchCtx, cancel := context.WithCancel(ctx)
 			watchClusterStopFns[ev.contextName] = cancel
 			go func() {
-				go watchRayClusters(watchCtx, ev.contextName, ev.kc, indexer, "ray.io/node-type=head", func(_ *v1.Service) bool { return true })
-				go watchRayClusters(watchCtx, ev.contextName, ev.kc, indexer, "anyscale-cloud-resource-id", func(s *v1.Service) bool { return strings.HasSuffix(s.GetName(), "-head") })
+				go watchRayClusters(watchCtx, ev.contextName, ev.kc, ev.restConfig, indexer, "ray.io/node-type=head", func(_ *v1.Service) bool { return true })
+				go watchRayClusters(watchCtx, ev.contextName, ev.kc, ev.restConfig, indexer, "anyscale-cloud-resource-id", func(s *v1.Service) bool { return strings.HasSuffix(s.GetName(), "-head") })
 				<-watchCtx.Done()
 				indexer.DeleteContext(ev.contextName)
 			}()
@@ -197,7 +234,40 @@ func WatchAllContexts(ctx context.Context, indexer *ClusterIndexer) {
 	}
 }
 
-func makeHandle(contextName string, kc *kubernetes.Clientset, svc corev1.Service) RayClusterHandle {
+// fanInSources merges the added/removed ClientEvent channels of every
+// source into one pair of channels.
+func fanInSources(ctx context.Context, sources []ClusterSource) (<-chan ClientEvent, <-chan ClientEvent) {
+	added := make(chan ClientEvent)
+	deleted := make(chan ClientEvent)
+
+	for _, source := range sources {
+		sourceAdded, sourceDeleted := source.Watch(ctx)
+		go forwardEvents(ctx, sourceAdded, added)
+		go forwardEvents(ctx, sourceDeleted, deleted)
+	}
+
+	return added, deleted
+}
+
+func forwardEvents(ctx context.Context, in <-chan ClientEvent, out chan<- ClientEvent) {
+	for {
+		select {
+		case ev, ok := <-in:
+			if !ok {
+				return
+			}
+			select {
+			case out <- ev:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func makeHandle(contextName string, kc *kubernetes.Clientset, restConfig *rest.Config, svc corev1.Service) RayClusterHandle {
 	rayClusterName := svc.GetLabels()["ray.io/cluster"]
 	if rayClusterName == "" {
 		svcName := svc.GetName()
@@ -210,11 +280,14 @@ func makeHandle(contextName string, kc *kubernetes.Clientset, svc corev1.Service
 	}
 
 	return RayClusterHandle{
-		kc:             kc,
-		RayClusterName: rayClusterName,
-		Namespace:      svc.GetNamespace(),
-		Service:        svc.GetName(),
-		UID:            string(svc.GetUID()),
-		ContextName:    contextName,
+		kc:                 kc,
+		restConfig:         restConfig,
+		RayClusterName:     rayClusterName,
+		Namespace:          svc.GetNamespace(),
+		Service:            svc.GetName(),
+		UID:                string(svc.GetUID()),
+		ContextName:        contextName,
+		ServiceLabels:      svc.GetLabels(),
+		ServiceAnnotations: svc.GetAnnotations(),
 	}
 }
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// rayClusterFleetGVR identifies the RayClusterFleet CRD that registers a
+// spoke cluster for multikuberay to watch.
+var rayClusterFleetGVR = schema.GroupVersionResource{
+	Group:    "multikuberay.dev",
+	Version:  "v1alpha1",
+	Resource: "rayclusterfleets",
+}
+
+// RayClusterFleetPhase is the last observed reachability of a registered
+// cluster.
+type RayClusterFleetPhase string
+
+const (
+	RayClusterFleetReady      RayClusterFleetPhase = "Ready"
+	RayClusterFleetOffline    RayClusterFleetPhase = "Offline"
+	RayClusterFleetAuthFailed RayClusterFleetPhase = "AuthFailed"
+)
+
+// RayClusterFleetConnection describes how to reach a registered cluster:
+// either directly with a kubeconfig, or through an outbound API proxy with
+// a bearer token.
+type RayClusterFleetConnection struct {
+	Type       string                    `json:"type"`
+	Kubeconfig *corev1.SecretKeySelector `json:"kubeconfig,omitempty"`
+	ProxyURL   string                    `json:"proxyURL,omitempty"`
+	Token      *corev1.SecretKeySelector `json:"token,omitempty"`
+}
+
+// RayClusterFleetSpec is the desired state of a registered cluster.
+type RayClusterFleetSpec struct {
+	Connection RayClusterFleetConnection `json:"connection"`
+}
+
+// RayClusterFleetStatus is the observed state of a registered cluster.
+type RayClusterFleetStatus struct {
+	Phase   RayClusterFleetPhase `json:"phase,omitempty"`
+	Message string               `json:"message,omitempty"`
+}
+
+// fleetSource is a ClusterSource backed by RayClusterFleet custom resources
+// in the cluster multikuberay itself runs in. It lets a shared deployment
+// register spoke clusters declaratively via kubectl apply, instead of
+// depending on the operator's laptop kubeconfig, and supports reaching
+// clusters that only expose an outbound proxy.
+type fleetSource struct {
+	hubKC  *kubernetes.Clientset
+	hubDyn dynamic.Interface
+}
+
+// newFleetSource builds a fleetSource from the pod's in-cluster
+// credentials. It returns an error when multikuberay isn't itself running
+// in a cluster, since there is then no hub to register fleets against.
+func newFleetSource() (*fleetSource, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("not running in-cluster: %w", err)
+	}
+	hubKC, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("build hub clientset: %w", err)
+	}
+	hubDyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("build hub dynamic client: %w", err)
+	}
+	return &fleetSource{hubKC: hubKC, hubDyn: hubDyn}, nil
+}
+
+func (f *fleetSource) Watch(ctx context.Context) (<-chan ClientEvent, <-chan ClientEvent) {
+	added := make(chan ClientEvent)
+	deleted := make(chan ClientEvent)
+
+	go func() {
+		defer close(added)
+		defer close(deleted)
+
+		healthStopFns := make(map[string]context.CancelFunc)
+		defer func() {
+			for _, cancel := range healthStopFns {
+				cancel()
+			}
+		}()
+		// lastSpecs tracks the last spec we reconciled per fleet, so that a
+		// Modified event caused by our own setStatus patch to .status (which
+		// leaves .spec untouched) doesn't re-emit an added ClientEvent and
+		// restart the health check loop.
+		lastSpecs := make(map[string]RayClusterFleetSpec)
+
+		fleets := f.hubDyn.Resource(rayClusterFleetGVR)
+
+		var resourceVersion string
+		list, err := fleets.Namespace(corev1.NamespaceAll).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			log.Printf("failed to list RayClusterFleets: %v", err)
+		} else {
+			resourceVersion = list.GetResourceVersion()
+			for i := range list.Items {
+				f.reconcile(ctx, &list.Items[i], added, healthStopFns, lastSpecs)
+			}
+		}
+
+		for ctx.Err() == nil {
+			watcher, err := fleets.Namespace(corev1.NamespaceAll).Watch(ctx, metav1.ListOptions{
+				ResourceVersion: resourceVersion,
+			})
+			if err != nil {
+				log.Printf("failed to watch RayClusterFleets: %v. Retrying...", err)
+				select {
+				case <-time.After(5 * time.Second):
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+
+			for event := range watcher.ResultChan() {
+				obj, ok := event.Object.(*unstructured.Unstructured)
+				if !ok {
+					continue
+				}
+				resourceVersion = obj.GetResourceVersion()
+
+				switch event.Type {
+				case watch.Added, watch.Modified:
+					f.reconcile(ctx, obj, added, healthStopFns, lastSpecs)
+				case watch.Deleted:
+					name := fleetKey(obj)
+					if cancel, ok := healthStopFns[name]; ok {
+						cancel()
+						delete(healthStopFns, name)
+					}
+					delete(lastSpecs, name)
+					fleetStatusMu.Lock()
+					delete(fleetStatuses, name)
+					fleetStatusMu.Unlock()
+					deleted <- ClientEvent{contextName: name}
+				}
+			}
+			log.Printf("RayClusterFleet watch closed, restarting.")
+		}
+	}()
+
+	return added, deleted
+}
+
+// fleetKey is the ClientEvent context name a RayClusterFleet is registered
+// under: its namespace/name, since fleet names are only unique per
+// namespace.
+func fleetKey(obj *unstructured.Unstructured) string {
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// reconcile builds a clientset for a RayClusterFleet's connection spec,
+// emits a ClientEvent for it, and (re)starts its health check loop. It's a
+// no-op if spec is unchanged since the last time this fleet was reconciled,
+// so that the Modified event our own healthCheck causes by patching .status
+// doesn't recreate the ClientEvent and health check loop every interval.
+func (f *fleetSource) reconcile(ctx context.Context, obj *unstructured.Unstructured, added chan<- ClientEvent, healthStopFns map[string]context.CancelFunc, lastSpecs map[string]RayClusterFleetSpec) {
+	name := fleetKey(obj)
+
+	specMap, found, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil || !found {
+		log.Printf("RayClusterFleet %s has no spec", name)
+		return
+	}
+	var spec RayClusterFleetSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(specMap, &spec); err != nil {
+		log.Printf("RayClusterFleet %s has an invalid spec: %v", name, err)
+		return
+	}
+
+	if prev, ok := lastSpecs[name]; ok && reflect.DeepEqual(prev, spec) {
+		// Nothing to do: we've already registered this exact spec, most
+		// likely re-triggered by our own healthCheck patching .status back
+		// onto the same object. Leave the existing ClientEvent and health
+		// check loop running rather than restarting them.
+		return
+	}
+
+	restConfig, err := f.buildRestConfig(ctx, obj.GetNamespace(), spec.Connection)
+	if err != nil {
+		log.Printf("RayClusterFleet %s: %v", name, err)
+		f.setStatus(ctx, obj.GetNamespace(), obj.GetName(), RayClusterFleetAuthFailed, err.Error())
+		setFleetStatus(name, RayClusterFleetStatus{Phase: RayClusterFleetAuthFailed, Message: err.Error()})
+		return
+	}
+
+	kc, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		log.Printf("RayClusterFleet %s: build clientset: %v", name, err)
+		f.setStatus(ctx, obj.GetNamespace(), obj.GetName(), RayClusterFleetAuthFailed, err.Error())
+		setFleetStatus(name, RayClusterFleetStatus{Phase: RayClusterFleetAuthFailed, Message: err.Error()})
+		return
+	}
+
+	lastSpecs[name] = spec
+
+	if cancel, ok := healthStopFns[name]; ok {
+		cancel()
+	}
+	healthCtx, cancel := context.WithCancel(ctx)
+	healthStopFns[name] = cancel
+	go f.healthCheck(healthCtx, obj.GetNamespace(), obj.GetName(), kc)
+
+	added <- ClientEvent{contextName: name, kc: kc, restConfig: restConfig}
+}
+
+// buildRestConfig resolves a RayClusterFleet's connection spec into a
+// rest.Config able to reach the registered cluster.
+func (f *fleetSource) buildRestConfig(ctx context.Context, namespace string, conn RayClusterFleetConnection) (*rest.Config, error) {
+	switch conn.Type {
+	case "proxy":
+		if conn.ProxyURL == "" {
+			return nil, fmt.Errorf("proxy connection missing proxyURL")
+		}
+		token, err := f.readSecretKey(ctx, namespace, conn.Token)
+		if err != nil {
+			return nil, fmt.Errorf("read proxy token: %w", err)
+		}
+		return &rest.Config{
+			Host:        conn.ProxyURL,
+			BearerToken: token,
+		}, nil
+	case "direct", "":
+		kubeconfigBytes, err := f.readSecretKeyBytes(ctx, namespace, conn.Kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("read kubeconfig secret: %w", err)
+		}
+		config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfigBytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse kubeconfig: %w", err)
+		}
+		return config, nil
+	default:
+		return nil, fmt.Errorf("unknown connection type %q", conn.Type)
+	}
+}
+
+func (f *fleetSource) readSecretKey(ctx context.Context, namespace string, ref *corev1.SecretKeySelector) (string, error) {
+	data, err := f.readSecretKeyBytes(ctx, namespace, ref)
+	return string(data), err
+}
+
+func (f *fleetSource) readSecretKeyBytes(ctx context.Context, namespace string, ref *corev1.SecretKeySelector) ([]byte, error) {
+	if ref == nil {
+		return nil, fmt.Errorf("secret reference not set")
+	}
+	secret, err := f.hubKC.CoreV1().Secrets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	data, ok := secret.Data[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("secret %s has no key %q", ref.Name, ref.Key)
+	}
+	return data, nil
+}
+
+// healthCheck pings the registered cluster's /version endpoint on an
+// interval and reflects the result back onto the RayClusterFleet's status,
+// as well as into the in-process fleetStatuses cache so multikuberay's own
+// dashboard can render it without re-reading the CR.
+func (f *fleetSource) healthCheck(ctx context.Context, namespace, name string, kc *kubernetes.Clientset) {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	contextName := namespace + "/" + name
+
+	check := func() {
+		_, err := kc.Discovery().ServerVersion()
+		phase, message := RayClusterFleetReady, ""
+		if err != nil {
+			phase, message = RayClusterFleetOffline, err.Error()
+		}
+		f.setStatus(ctx, namespace, name, phase, message)
+		setFleetStatus(contextName, RayClusterFleetStatus{Phase: phase, Message: message})
+	}
+
+	check()
+	for {
+		select {
+		case <-ticker.C:
+			check()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// fleetStatuses caches the last-observed RayClusterFleetStatus per context
+// name, so that readers in this process (e.g. the dashboard) can look up a
+// fleet's reachability without going back out to the hub cluster's API
+// server for the CR. It's populated by healthCheck and by reconcile's
+// AuthFailed path above.
+var (
+	fleetStatusMu sync.Mutex
+	fleetStatuses = make(map[string]RayClusterFleetStatus)
+)
+
+// setFleetStatus records contextName's latest observed fleet status.
+func setFleetStatus(contextName string, status RayClusterFleetStatus) {
+	fleetStatusMu.Lock()
+	defer fleetStatusMu.Unlock()
+	fleetStatuses[contextName] = status
+}
+
+// FleetStatus returns the last-known reachability multikuberay has observed
+// for contextName, and whether contextName was ever sourced from a
+// RayClusterFleet at all (ClientEvents from the kubeconfig poller have no
+// entry here).
+func FleetStatus(contextName string) (RayClusterFleetStatus, bool) {
+	fleetStatusMu.Lock()
+	defer fleetStatusMu.Unlock()
+	status, ok := fleetStatuses[contextName]
+	return status, ok
+}
+
+// setStatus server-side-applies a RayClusterFleet's status subresource with
+// its latest reachability.
+func (f *fleetSource) setStatus(ctx context.Context, namespace, name string, phase RayClusterFleetPhase, message string) {
+	status := RayClusterFleetStatus{Phase: phase, Message: message}
+	statusMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&status)
+	if err != nil {
+		log.Printf("failed to encode status for RayClusterFleet %s/%s: %v", namespace, name, err)
+		return
+	}
+
+	patch := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": rayClusterFleetGVR.GroupVersion().String(),
+		"kind":       "RayClusterFleet",
+		"metadata": map[string]any{
+			"name":      name,
+			"namespace": namespace,
+		},
+		"status": statusMap,
+	}}
+
+	_, err = f.hubDyn.Resource(rayClusterFleetGVR).Namespace(namespace).ApplyStatus(ctx, name, patch, metav1.ApplyOptions{FieldManager: "multikuberay"})
+	if err != nil {
+		log.Printf("failed to update status for RayClusterFleet %s/%s: %v", namespace, name, err)
+	}
+}
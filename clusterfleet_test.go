@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestFleetKey(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{
+			"namespace": "team-a",
+			"name":      "prod-cluster",
+		},
+	}}
+
+	if got, want := fleetKey(obj), "team-a/prod-cluster"; got != want {
+		t.Errorf("fleetKey() = %q, want %q", got, want)
+	}
+}
+
+func TestFleetStatusRoundTrip(t *testing.T) {
+	const contextName = "test-ns/test-fleet"
+
+	if _, ok := FleetStatus(contextName); ok {
+		t.Fatalf("FleetStatus(%q) reported ok before any status was set", contextName)
+	}
+
+	setFleetStatus(contextName, RayClusterFleetStatus{Phase: RayClusterFleetReady, Message: "ok"})
+
+	status, ok := FleetStatus(contextName)
+	if !ok {
+		t.Fatalf("FleetStatus(%q) = (_, false), want true after setFleetStatus", contextName)
+	}
+	if status.Phase != RayClusterFleetReady || status.Message != "ok" {
+		t.Errorf("FleetStatus(%q) = %+v, want Phase=%q Message=%q", contextName, status, RayClusterFleetReady, "ok")
+	}
+}
@@ -49,6 +49,27 @@ func (c *ClusterIndexer) Insert(ctx context.Context, cluster RayClusterHandle) {
 	c.forwardStopFns[cluster.UID] = cancel
 }
 
+// SetReady records whether the port tunnel for the cluster identified by
+// contextName and uid is actually live. It's called by the PortForward
+// goroutine that owns that cluster's forwarding as its readyCh fires and
+// clears, so that consumers of List/Lookup/FuzzyMatch can tell an
+// allocated-but-not-yet-dialable port apart from a live one.
+func (c *ClusterIndexer) SetReady(contextName, uid string, ready bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	clusters, ok := c.clusterTree[contextName]
+	if !ok {
+		return
+	}
+	cluster, ok := clusters[uid]
+	if !ok {
+		return
+	}
+	cluster.Ready = ready
+	clusters[uid] = cluster
+}
+
 func (c *ClusterIndexer) Delete(contextName string, uid string) {
 	c.m.Lock()
 	defer c.m.Unlock()
@@ -89,6 +110,19 @@ func (c *ClusterIndexer) List() map[string]map[string]RayClusterHandle {
 	return result
 }
 
+// Lookup finds a cluster by UID regardless of which context it came from.
+func (c *ClusterIndexer) Lookup(uid string) (RayClusterHandle, bool) {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	for _, clusters := range c.clusterTree {
+		if handle, ok := clusters[uid]; ok {
+			return handle, true
+		}
+	}
+	return RayClusterHandle{}, false
+}
+
 func (c *ClusterIndexer) FuzzyMatch(in string) []RayClusterHandle {
 	c.m.RLock()
 	defer c.m.RUnlock()
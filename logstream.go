@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// rayHeadContainer and rayWorkerContainer are the container names KubeRay
+// gives the main container of a head and a worker pod respectively.
+const (
+	rayHeadContainer   = "ray-head"
+	rayWorkerContainer = "ray-worker"
+)
+
+// containerForPod picks the container to tail logs from based on pod's
+// ray.io/node-type label, since KubeRay names a pod's main container
+// differently depending on whether it's the head or a worker. Pods with no
+// recognized node-type are assumed to be a head, matching the label
+// watchRayClusters already selects on by default.
+func containerForPod(pod *v1.Pod) string {
+	if pod.Labels["ray.io/node-type"] == "worker" {
+		return rayWorkerContainer
+	}
+	return rayHeadContainer
+}
+
+// podInformers caches one shared pod informer per kube context, so that
+// multiple concurrent LogStreamers against clusters in the same context
+// reuse a single List+Watch against the API server instead of each opening
+// their own.
+var (
+	podInformersMu sync.Mutex
+	podInformers   = make(map[string]cache.SharedIndexInformer)
+)
+
+// podInformerFor returns the shared, running pod informer for
+// handle.ContextName, starting one the first time the context is seen. The
+// informer watches every namespace in the context and lives for the life of
+// the process; callers filter to the pods they care about in their own
+// event handlers.
+func podInformerFor(handle RayClusterHandle) cache.SharedIndexInformer {
+	podInformersMu.Lock()
+	defer podInformersMu.Unlock()
+
+	if informer, ok := podInformers[handle.ContextName]; ok {
+		return informer
+	}
+
+	factory := informers.NewSharedInformerFactory(handle.kc, 0)
+	informer := factory.Core().V1().Pods().Informer()
+	podInformers[handle.ContextName] = informer
+
+	// This factory is never stopped; it's cached for the life of the
+	// process alongside the informer it produced.
+	factory.Start(nil)
+
+	return informer
+}
+
+// LogRecord is a single line of log output from one ray pod, tagged with
+// enough information for a consumer to tell which pod it came from.
+type LogRecord struct {
+	Pod       string    `json:"pod"`
+	Line      string    `json:"line"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// LogStreamer multiplexes the logs of every ray pod in a cluster into a
+// single ordered channel, picking up pods that start after the stream
+// begins and tearing down goroutines for pods that are deleted.
+type LogStreamer struct {
+	handle       RayClusterHandle
+	sinceSeconds *int64
+}
+
+// NewLogStreamer returns a LogStreamer for the pods backing handle's ray
+// cluster. sinceSeconds is passed through to PodLogOptions.SinceSeconds for
+// each pod followed; nil means "from the start of the container's log".
+func NewLogStreamer(handle RayClusterHandle, sinceSeconds *int64) *LogStreamer {
+	return &LogStreamer{handle: handle, sinceSeconds: sinceSeconds}
+}
+
+// Stream starts following logs for every ray pod in the cluster and sends
+// merged LogRecords to the returned channel until ctx is cancelled, at which
+// point the channel is closed.
+func (l *LogStreamer) Stream(ctx context.Context) <-chan LogRecord {
+	out := make(chan LogRecord)
+
+	go func() {
+		var wg sync.WaitGroup
+		defer func() {
+			// Wait for every followPod goroutine we started to actually
+			// return before closing out, so none of them can land on the
+			// "send on out" case of a select racing this close.
+			wg.Wait()
+			close(out)
+		}()
+
+		podInformer := podInformerFor(l.handle)
+
+		var mu sync.Mutex
+		stopFns := make(map[string]context.CancelFunc)
+
+		matches := func(pod *v1.Pod) bool {
+			return pod.Namespace == l.handle.Namespace && pod.Labels["ray.io/cluster"] == l.handle.RayClusterName
+		}
+
+		reg, err := podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj any) {
+				pod, ok := obj.(*v1.Pod)
+				if !ok || !matches(pod) {
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if _, ok := stopFns[pod.Name]; ok {
+					return
+				}
+				podCtx, cancel := context.WithCancel(ctx)
+				stopFns[pod.Name] = cancel
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					l.followPod(podCtx, pod.Name, containerForPod(pod), out)
+				}()
+			},
+			DeleteFunc: func(obj any) {
+				pod, ok := obj.(*v1.Pod)
+				if !ok {
+					tomb, ok := obj.(cache.DeletedFinalStateUnknown)
+					if !ok {
+						return
+					}
+					pod, ok = tomb.Obj.(*v1.Pod)
+					if !ok {
+						return
+					}
+				}
+				if !matches(pod) {
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if cancel, ok := stopFns[pod.Name]; ok {
+					cancel()
+					delete(stopFns, pod.Name)
+				}
+			},
+		})
+		if err != nil {
+			log.Printf("failed to watch pods for %s/%s: %v", l.handle.ContextName, l.handle.RayClusterName, err)
+			return
+		}
+		defer podInformer.RemoveEventHandler(reg)
+
+		if !cache.WaitForCacheSync(ctx.Done(), podInformer.HasSynced) {
+			return
+		}
+
+		<-ctx.Done()
+
+		mu.Lock()
+		for _, cancel := range stopFns {
+			cancel()
+		}
+		mu.Unlock()
+	}()
+
+	return out
+}
+
+// followPod tails podName's container (the head or worker container,
+// depending on container) and forwards each line to out, tagged with the
+// pod name and a timestamp, until ctx is cancelled or the log stream ends.
+func (l *LogStreamer) followPod(ctx context.Context, podName, container string, out chan<- LogRecord) {
+	req := l.handle.kc.CoreV1().Pods(l.handle.Namespace).GetLogs(podName, &v1.PodLogOptions{
+		Follow:       true,
+		Container:    container,
+		SinceSeconds: l.sinceSeconds,
+	})
+
+	stream, err := req.Stream(ctx)
+	if err != nil {
+		log.Printf("failed to stream logs for pod %s: %v", podName, err)
+		return
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		record := LogRecord{
+			Pod:       podName,
+			Line:      scanner.Text(),
+			Timestamp: time.Now(),
+		}
+		select {
+		case out <- record:
+		case <-ctx.Done():
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		log.Printf("log stream for pod %s ended: %v", podName, err)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestContainerForPod(t *testing.T) {
+	cases := []struct {
+		name string
+		pod  *v1.Pod
+		want string
+	}{
+		{
+			name: "head node-type",
+			pod:  &v1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"ray.io/node-type": "head"}}},
+			want: rayHeadContainer,
+		},
+		{
+			name: "worker node-type",
+			pod:  &v1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"ray.io/node-type": "worker"}}},
+			want: rayWorkerContainer,
+		},
+		{
+			name: "no node-type label falls back to head",
+			pod:  &v1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}}},
+			want: rayHeadContainer,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := containerForPod(tc.pod); got != tc.want {
+				t.Errorf("containerForPod() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	_ "embed"
 	"encoding/json"
@@ -12,9 +13,12 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 )
 
 // PageData holds the dynamic data for the template.
@@ -23,6 +27,13 @@ type PageData struct {
 	TargetUID   string
 	TargetName  string
 	IframePath  string
+	// FleetStatuses holds the last-observed RayClusterFleet reachability,
+	// keyed by context name, for every context in ClusterTree that came
+	// from a RayClusterFleet registration rather than the kubeconfig
+	// poller. index.html doesn't exist in this tree to render it yet, but
+	// the data is wired through so that template can be added without
+	// further plumbing.
+	FleetStatuses map[string]RayClusterFleetStatus
 }
 
 var (
@@ -37,12 +48,25 @@ var (
 
 type RayClusterHandle struct {
 	kc             *kubernetes.Clientset
+	restConfig     *rest.Config
 	RayClusterName string
 	Namespace      string
 	Service        string
 	ContextName    string
 	UID            string
 	Port           *int
+	// Ready reports whether the tunnel behind Port is actually live, as
+	// opposed to merely allocated. It's flipped by the PortForward
+	// goroutine via ClusterIndexer.SetReady as its portforward.ForwardPorts
+	// readyCh fires and clears; consumers of Port must also check Ready
+	// before dialing it.
+	Ready bool
+
+	// ServiceLabels and ServiceAnnotations are copied from the head Service
+	// so consumers like the Prometheus SD endpoint can surface them without
+	// re-fetching the Service from the cluster.
+	ServiceLabels      map[string]string
+	ServiceAnnotations map[string]string
 }
 
 func init() {
@@ -63,6 +87,10 @@ func main() {
 		serveMain()
 	case "run":
 		runMain()
+	case "logs":
+		logsMain()
+	case "submit":
+		submitMain()
 	}
 }
 
@@ -70,12 +98,21 @@ func serveMain() {
 	ctx := context.Background()
 	portMapper = NewPortAllocater(8270)
 	indexer = NewClusterIndexer(portMapper)
-	go WatchAllContexts(ctx, indexer)
+
+	sources := []ClusterSource{kubeconfigSource{}}
+	if fleet, err := newFleetSource(); err != nil {
+		log.Printf("RayClusterFleet registration disabled: %v", err)
+	} else {
+		sources = append(sources, fleet)
+	}
+	go WatchAllContexts(ctx, indexer, sources...)
 
 	http.HandleFunc("/", handleIndex)
 	http.HandleFunc("/dash/{uid}", handleDashboard)
 	http.HandleFunc("/proxy/{uid}/", handleProxy)
 	http.HandleFunc("/api/v1/match", handleMatch)
+	http.HandleFunc("/api/v1/sd/prometheus", handlePrometheusSD)
+	http.HandleFunc("/api/v1/logs/{uid}", handleLogs)
 	http.HandleFunc("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "image/svg+xml")
 		w.Write(faviconBytes)
@@ -108,9 +145,9 @@ func handleDashboard(w http.ResponseWriter, r *http.Request) {
 	// Need to generate a tree by project > location > cluster name > raycluster
 	clusterTree := indexer.List()
 	newTree := make(map[string]map[string]map[string]map[string]RayClusterHandle)
+	fleetStatuses := make(map[string]RayClusterFleetStatus)
 	for contextName, clusters := range clusterTree {
-		parts := strings.Split(contextName, "_")
-		project, location, clusterName := parts[1], parts[2], parts[3]
+		project, location, clusterName := contextTreeKeys(contextName)
 		if _, ok := newTree[project]; !ok {
 			newTree[project] = make(map[string]map[string]map[string]RayClusterHandle)
 		}
@@ -122,6 +159,10 @@ func handleDashboard(w http.ResponseWriter, r *http.Request) {
 		}
 		newTree[project][location][clusterName] = clusters
 
+		if status, ok := FleetStatus(contextName); ok {
+			fleetStatuses[contextName] = status
+		}
+
 		for _, cluster := range clusters {
 			if cluster.UID == uid {
 				targetClusterName = cluster.RayClusterName
@@ -131,10 +172,11 @@ func handleDashboard(w http.ResponseWriter, r *http.Request) {
 
 	// Create the data object.
 	data := PageData{
-		ClusterTree: newTree,
-		TargetUID:   uid,
-		TargetName:  targetClusterName,
-		IframePath:  findIframePath(r),
+		ClusterTree:   newTree,
+		TargetUID:     uid,
+		TargetName:    targetClusterName,
+		IframePath:    findIframePath(r),
+		FleetStatuses: fleetStatuses,
 	}
 
 	// Execute the template with the data
@@ -145,6 +187,20 @@ func handleDashboard(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// contextTreeKeys splits a context name into the project/location/cluster
+// grouping the dashboard's tree view is keyed by. GKE context names look
+// like "gke_project_zone_cluster"; any other shape a ClusterSource can mint
+// (e.g. a RayClusterFleet's "namespace/name") doesn't carry that structure,
+// so it's grouped under a flat "other" project/location instead of
+// panicking on a too-short parts slice.
+func contextTreeKeys(contextName string) (project, location, clusterName string) {
+	parts := strings.Split(contextName, "_")
+	if len(parts) >= 4 && parts[0] == "gke" {
+		return parts[1], parts[2], strings.Join(parts[3:], "_")
+	}
+	return "other", "other", contextName
+}
+
 func findIframePath(r *http.Request) string {
 	cookie, err := r.Cookie("last_known_iframe_location")
 	if err != nil {
@@ -170,32 +226,66 @@ func handleMatch(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func runMain() {
-	// The command is something like "run my-cluster -- python3 myfile.py"
-	// The cluster name is optional.
-	// Find the cluster name argument (if present) and then store the rest of
-	// the command after -- in another var.
-	var prefix string
-	var command []string
-	if len(os.Args) > 2 {
-		if os.Args[2] != "--" {
-			prefix = os.Args[2]
-		}
-	}
+// prometheusSDTarget is one entry in Prometheus's HTTP service discovery
+// format: https://prometheus.io/docs/prometheus/latest/http_sd/.
+type prometheusSDTarget struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
 
-	for i, arg := range os.Args {
-		if arg == "--" {
-			command = os.Args[i+1:]
-			break
+func handlePrometheusSD(w http.ResponseWriter, r *http.Request) {
+	targets := []prometheusSDTarget{}
+	for contextName, clusters := range indexer.List() {
+		for _, cluster := range clusters {
+			if cluster.Port == nil || !cluster.Ready {
+				// Not forwarded yet, or the tunnel isn't live, so there's
+				// nothing to scrape.
+				continue
+			}
+
+			labels := map[string]string{
+				"__meta_kubernetes_namespace":      cluster.Namespace,
+				"__meta_kubernetes_service_name":   cluster.Service,
+				"__meta_multikuberay_context":      contextName,
+				"__meta_multikuberay_ray_cluster":  cluster.RayClusterName,
+				"__meta_multikuberay_uid":          cluster.UID,
+				"__meta_multikuberay_forward_port": fmt.Sprint(*cluster.Port),
+			}
+			for key, value := range cluster.ServiceLabels {
+				labels["__meta_kubernetes_service_label_"+sanitizeLabelName(key)] = value
+			}
+
+			targets = append(targets, prometheusSDTarget{
+				Targets: []string{fmt.Sprintf("localhost:%d", *cluster.Port)},
+				Labels:  labels,
+			})
 		}
 	}
 
-	if len(command) == 0 {
-		fmt.Fprintf(os.Stderr, "no command specified\n")
-		os.Exit(1)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(targets); err != nil {
+		http.Error(w, "Failed to encode service discovery targets to json", http.StatusInternalServerError)
 	}
+}
 
-	// Fetch the match handler with the given prefix on localhost port 8080.
+// sanitizeLabelName replaces characters that aren't valid in a Prometheus
+// label name with underscores, mirroring how Prometheus itself sanitizes
+// Kubernetes label keys for SD output.
+func sanitizeLabelName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// resolveCluster hits the local server's match endpoint to find exactly one
+// cluster whose name has the given prefix. It prints a helpful error and
+// exits the process on no match or an ambiguous match.
+func resolveCluster(prefix string) RayClusterHandle {
 	resp, err := http.Get("http://localhost:8080/api/v1/match?prefix=" + prefix)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to connect to multikuberay server: %v\n", err)
@@ -223,12 +313,44 @@ func runMain() {
 		os.Exit(1)
 	}
 
-	// If there is one match, identify its target port.
-	match := matches[0]
+	return matches[0]
+}
+
+func runMain() {
+	// The command is something like "run my-cluster -- python3 myfile.py"
+	// The cluster name is optional.
+	// Find the cluster name argument (if present) and then store the rest of
+	// the command after -- in another var.
+	var prefix string
+	var command []string
+	if len(os.Args) > 2 {
+		if os.Args[2] != "--" {
+			prefix = os.Args[2]
+		}
+	}
+
+	for i, arg := range os.Args {
+		if arg == "--" {
+			command = os.Args[i+1:]
+			break
+		}
+	}
+
+	if len(command) == 0 {
+		fmt.Fprintf(os.Stderr, "no command specified\n")
+		os.Exit(1)
+	}
+
+	// Identify the target cluster's forwarded port.
+	match := resolveCluster(prefix)
 	if match.Port == nil {
 		fmt.Fprintf(os.Stderr, "cluster has no port assigned\n")
 		os.Exit(1)
 	}
+	if !match.Ready {
+		fmt.Fprintf(os.Stderr, "cluster's port forward is not ready yet, try again shortly\n")
+		os.Exit(1)
+	}
 	port := *match.Port
 
 	// Set up a command to run the user's command.
@@ -239,7 +361,7 @@ func runMain() {
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
-	err = cmd.Run()
+	err := cmd.Run()
 	exitErr := &exec.ExitError{}
 	if errors.As(err, &exitErr) {
 		os.Exit(exitErr.ExitCode())
@@ -249,3 +371,208 @@ func runMain() {
 		os.Exit(1)
 	}
 }
+
+// handleLogs streams the merged, tagged logs of every pod in a ray cluster
+// to the client as a Server-Sent Events stream of JSON-encoded LogRecords.
+// This is consumed today by the `logs` CLI subcommand; the dashboard
+// (index.html) doesn't yet have a client that connects to it and renders a
+// live tail inline, so that part of embedding logs "in the dashboard page"
+// is still unscoped follow-up work, not done by this endpoint alone.
+func handleLogs(w http.ResponseWriter, r *http.Request) {
+	uid := r.PathValue("uid")
+	if uid == "" {
+		http.Error(w, "no uid", http.StatusBadRequest)
+		return
+	}
+
+	handle, ok := indexer.Lookup(uid)
+	if !ok {
+		http.Error(w, "uid not found", http.StatusNotFound)
+		return
+	}
+
+	var sinceSeconds *int64
+	if since := r.URL.Query().Get("since"); since != "" {
+		seconds, err := strconv.ParseInt(since, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid since", http.StatusBadRequest)
+			return
+		}
+		sinceSeconds = &seconds
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	streamer := NewLogStreamer(handle, sinceSeconds)
+	for record := range streamer.Stream(r.Context()) {
+		data, err := json.Marshal(record)
+		if err != nil {
+			log.Printf("failed to encode log record: %v", err)
+			continue
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+	}
+}
+
+func logsMain() {
+	// The command is something like "logs my-cluster --since=30s".
+	// The cluster name is optional.
+	var prefix string
+	var since time.Duration
+	for _, arg := range os.Args[2:] {
+		switch {
+		case strings.HasPrefix(arg, "--since="):
+			d, err := time.ParseDuration(strings.TrimPrefix(arg, "--since="))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "invalid --since duration: %v\n", err)
+				os.Exit(1)
+			}
+			since = d
+		default:
+			prefix = arg
+		}
+	}
+
+	match := resolveCluster(prefix)
+
+	logsURL := fmt.Sprintf("http://localhost:8080/api/v1/logs/%s", match.UID)
+	if since > 0 {
+		logsURL += fmt.Sprintf("?since=%d", int64(since.Seconds()))
+	}
+
+	resp, err := http.Get(logsURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to multikuberay server: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		var record LogRecord
+		if err := json.Unmarshal([]byte(data), &record); err != nil {
+			continue
+		}
+		fmt.Printf("[%s] %s\n", record.Pod, record.Line)
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "log stream ended: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func submitMain() {
+	// The command is something like
+	// "submit my-cluster --working-dir=. --wait=false -- python3 myfile.py".
+	var prefix string
+	var workingDir string
+	wait := true
+	var command []string
+
+	args := os.Args[2:]
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--":
+			command = args[i+1:]
+			i = len(args)
+		case strings.HasPrefix(arg, "--working-dir="):
+			workingDir = strings.TrimPrefix(arg, "--working-dir=")
+		case strings.HasPrefix(arg, "--wait="):
+			w, err := strconv.ParseBool(strings.TrimPrefix(arg, "--wait="))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "invalid --wait value: %v\n", err)
+				os.Exit(1)
+			}
+			wait = w
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) > 0 {
+		prefix = positional[0]
+	}
+	if len(command) == 0 {
+		fmt.Fprintf(os.Stderr, "no entrypoint specified\n")
+		os.Exit(1)
+	}
+
+	match := resolveCluster(prefix)
+
+	submission := RayJobSubmission{Entrypoint: shellQuoteJoin(command)}
+	if workingDir != "" {
+		// There's no pip-uploadable package store configured for --working-dir
+		// yet, so this intentionally only supports the case where the
+		// submitter and the Ray cluster share a filesystem: we pass the
+		// directory through as a local path, which the Ray Jobs API agent
+		// resolves on the cluster side. Zip-and-upload support, so
+		// --working-dir also works against clusters that don't share a
+		// filesystem with the submitter, is unimplemented follow-up work.
+		fmt.Fprintf(os.Stderr, "note: --working-dir is passed through as a local path; it requires the ray cluster to share a filesystem with this machine\n")
+		submission.RuntimeEnv = map[string]any{"working_dir": workingDir}
+	}
+
+	submissionID, err := submitRayJob(match.UID, submission)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to submit job: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !wait {
+		fmt.Println(submissionID)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "submitted job %s, waiting for completion...\n", submissionID)
+
+	var printedLogs int
+	for {
+		if logs, err := rayJobLogs(match.UID, submissionID); err == nil && len(logs) > printedLogs {
+			fmt.Print(logs[printedLogs:])
+			printedLogs = len(logs)
+		}
+
+		status, err := rayJobStatus(match.UID, submissionID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to check job status: %v\n", err)
+			os.Exit(1)
+		}
+		if rayJobTerminal(status.Status) {
+			if status.Status != rayJobSucceeded {
+				fmt.Fprintf(os.Stderr, "job ended with status %s: %s\n", status.Status, status.Message)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// shellQuoteJoin joins args into a single command line, single-quoting each
+// argument so that the Ray Jobs API agent (which runs the entrypoint through
+// a shell on the cluster) sees the same argument boundaries the user typed,
+// instead of the quoted words being rejoined with bare spaces and re-split.
+func shellQuoteJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'"'"'`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
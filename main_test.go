@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestContextTreeKeys(t *testing.T) {
+	cases := []struct {
+		name                                    string
+		contextName                             string
+		wantProject, wantLocation, wantClusterName string
+	}{
+		{
+			name:            "gke context",
+			contextName:     "gke_my-project_us-central1-a_my-cluster",
+			wantProject:     "my-project",
+			wantLocation:    "us-central1-a",
+			wantClusterName: "my-cluster",
+		},
+		{
+			name:            "gke context with underscores in cluster name",
+			contextName:     "gke_my-project_us-central1-a_my_cluster_name",
+			wantProject:     "my-project",
+			wantLocation:    "us-central1-a",
+			wantClusterName: "my_cluster_name",
+		},
+		{
+			name:            "fleet context has no underscores",
+			contextName:     "team-a/prod-cluster",
+			wantProject:     "other",
+			wantLocation:    "other",
+			wantClusterName: "team-a/prod-cluster",
+		},
+		{
+			name:            "short underscored context that isn't gke-shaped",
+			contextName:     "a_b",
+			wantProject:     "other",
+			wantLocation:    "other",
+			wantClusterName: "a_b",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			project, location, clusterName := contextTreeKeys(tc.contextName)
+			if project != tc.wantProject || location != tc.wantLocation || clusterName != tc.wantClusterName {
+				t.Errorf("contextTreeKeys(%q) = (%q, %q, %q), want (%q, %q, %q)",
+					tc.contextName, project, location, clusterName,
+					tc.wantProject, tc.wantLocation, tc.wantClusterName)
+			}
+		})
+	}
+}
@@ -3,37 +3,48 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
-	"os/exec"
+	"net/http"
 	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
 )
 
+// PortForward keeps a tunnel from the local port to handle's Ray head pod
+// alive for as long as ctx is valid. It resolves the head pod behind
+// handle.Service, forwards to it over an SPDY upgrade, and restarts the
+// tunnel whenever it fails or the target pod goes away.
 func PortForward(ctx context.Context, port int, handle RayClusterHandle) {
-	kubectlArgs := []string{
-		"--context", handle.ContextName,
-		"port-forward",
-		"-n", handle.Namespace,
-		"service/" + handle.Service,
-		fmt.Sprintf("%d:8265", port),
-	}
-
 	const initBackoff = 10 * time.Millisecond
 	const maxBackoff = 30 * time.Second
 	backoff := initBackoff
 	lastErr := time.Now()
 
+	defer indexer.SetReady(handle.ContextName, handle.UID, false)
+
 	for ctx.Err() == nil {
-		portforwardCmd := exec.CommandContext(ctx, "kubectl", kubectlArgs...)
-		out, err := portforwardCmd.CombinedOutput()
+		indexer.SetReady(handle.ContextName, handle.UID, false)
+		podName, err := resolveHeadPod(ctx, handle)
 		if err == nil {
-			continue
+			err = forwardToPod(ctx, port, handle, podName)
 		}
-
-		log.Printf("port forward %s/%s/%s failed: %v", handle.ContextName, handle.Namespace, handle.RayClusterName, err)
-		log.Printf("port forward output: %s", out)
 		if ctx.Err() != nil {
 			return
 		}
+		if err == nil {
+			// The forward only returns nil once the target pod has changed;
+			// go straight back around to resolve the new one.
+			backoff = initBackoff
+			continue
+		}
+
+		log.Printf("port forward %s/%s/%s failed: %v", handle.ContextName, handle.Namespace, handle.RayClusterName, err)
 
 		now := time.Now()
 		if now.Sub(lastErr) > 1*time.Minute {
@@ -47,3 +58,134 @@ func PortForward(ctx context.Context, port int, handle RayClusterHandle) {
 		<-time.After(backoff)
 	}
 }
+
+// resolveHeadPod looks up handle.Service's selector and returns the name of
+// a running pod backing it.
+func resolveHeadPod(ctx context.Context, handle RayClusterHandle) (string, error) {
+	svc, err := handle.kc.CoreV1().Services(handle.Namespace).Get(ctx, handle.Service, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("get service: %w", err)
+	}
+	if len(svc.Spec.Selector) == 0 {
+		return "", fmt.Errorf("service %s has no selector", handle.Service)
+	}
+
+	pods, err := handle.kc.CoreV1().Pods(handle.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(svc.Spec.Selector).String(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("list pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == v1.PodRunning && pod.DeletionTimestamp == nil {
+			return pod.GetName(), nil
+		}
+	}
+	return "", fmt.Errorf("no running pod for service %s", handle.Service)
+}
+
+// forwardToPod opens an SPDY tunnel to podName and blocks until ctx is
+// cancelled or the service's endpoints stop pointing at podName, in which
+// case it returns nil so the caller re-resolves the head pod.
+func forwardToPod(ctx context.Context, port int, handle RayClusterHandle, podName string) error {
+	roundTripper, upgrader, err := spdy.RoundTripperFor(handle.restConfig)
+	if err != nil {
+		return fmt.Errorf("build spdy round tripper: %w", err)
+	}
+
+	req := handle.kc.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(handle.Namespace).
+		Name(podName).
+		SubResource("portforward")
+
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: roundTripper}, http.MethodPost, req.URL())
+
+	stopCh := make(chan struct{})
+	readyCh := make(chan struct{})
+	errCh := make(chan error, 1)
+
+	fw, err := portforward.New(dialer, []string{fmt.Sprintf("%d:8265", port)}, stopCh, readyCh, io.Discard, io.Discard)
+	if err != nil {
+		return fmt.Errorf("create port forwarder: %w", err)
+	}
+
+	go func() { errCh <- fw.ForwardPorts() }()
+
+	stoppedWatch := make(chan struct{})
+	go func() {
+		defer close(stopCh)
+		waitForPodChange(ctx, handle, podName)
+		close(stoppedWatch)
+	}()
+
+	select {
+	case <-readyCh:
+		indexer.SetReady(handle.ContextName, handle.UID, true)
+		log.Printf("port forward %s/%s/%s is ready on pod %s", handle.ContextName, handle.Namespace, handle.RayClusterName, podName)
+	case err := <-errCh:
+		return err
+	}
+
+	err = <-errCh
+	select {
+	case <-stoppedWatch:
+		// We stopped the forwarder ourselves because the pod changed; treat
+		// this as expected so the caller re-resolves without backing off.
+		return nil
+	default:
+		return err
+	}
+}
+
+// waitForPodChange blocks until ctx is cancelled or the service's endpoints
+// no longer list podName as a ready target.
+func waitForPodChange(ctx context.Context, handle RayClusterHandle, podName string) {
+	for ctx.Err() == nil {
+		watcher, err := handle.kc.CoreV1().Endpoints(handle.Namespace).Watch(ctx, metav1.ListOptions{
+			FieldSelector: "metadata.name=" + handle.Service,
+		})
+		if err != nil {
+			log.Printf("watch endpoints %s/%s: %v", handle.Namespace, handle.Service, err)
+			<-ctx.Done()
+			return
+		}
+
+	events:
+		for {
+			select {
+			case event, ok := <-watcher.ResultChan():
+				if !ok {
+					// The server ended the watch; open a new one rather than
+					// treating it as a pod change.
+					break events
+				}
+				endpoints, ok := event.Object.(*v1.Endpoints)
+				if !ok {
+					continue
+				}
+				if event.Type == watch.Deleted || !endpointsTargetPod(endpoints, podName) {
+					watcher.Stop()
+					return
+				}
+			case <-ctx.Done():
+				watcher.Stop()
+				return
+			}
+		}
+		watcher.Stop()
+	}
+}
+
+// endpointsTargetPod reports whether podName is still among endpoints' ready
+// addresses.
+func endpointsTargetPod(endpoints *v1.Endpoints, podName string) bool {
+	for _, subset := range endpoints.Subsets {
+		for _, addr := range subset.Addresses {
+			if addr.TargetRef != nil && addr.TargetRef.Kind == "Pod" && addr.TargetRef.Name == podName {
+				return true
+			}
+		}
+	}
+	return false
+}
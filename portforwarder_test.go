@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestEndpointsTargetPod(t *testing.T) {
+	endpoints := &v1.Endpoints{
+		Subsets: []v1.EndpointSubset{
+			{
+				Addresses: []v1.EndpointAddress{
+					{TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "head-abc"}},
+					{TargetRef: &v1.ObjectReference{Kind: "Node", Name: "head-abc"}},
+					{TargetRef: nil},
+				},
+			},
+		},
+	}
+
+	if !endpointsTargetPod(endpoints, "head-abc") {
+		t.Errorf("endpointsTargetPod() = false, want true for a pod listed in subset addresses")
+	}
+	if endpointsTargetPod(endpoints, "head-xyz") {
+		t.Errorf("endpointsTargetPod() = true, want false for a pod not listed")
+	}
+	if endpointsTargetPod(&v1.Endpoints{}, "head-abc") {
+		t.Errorf("endpointsTargetPod() = true, want false for endpoints with no subsets")
+	}
+}
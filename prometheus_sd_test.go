@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestSanitizeLabelName(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "already valid", in: "ray_io_cluster", want: "ray_io_cluster"},
+		{name: "dots and slashes", in: "ray.io/cluster", want: "ray_io_cluster"},
+		{name: "leading digit kept", in: "2nd-label", want: "2nd_label"},
+		{name: "mixed case kept", in: "MyLabel", want: "MyLabel"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := sanitizeLabelName(tc.in); got != tc.want {
+				t.Errorf("sanitizeLabelName(%q) = %q, want %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
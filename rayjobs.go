@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// RayJobSubmission is the request body for the Ray Jobs API's
+// POST /api/jobs/ endpoint.
+type RayJobSubmission struct {
+	Entrypoint   string            `json:"entrypoint"`
+	RuntimeEnv   map[string]any    `json:"runtime_env,omitempty"`
+	SubmissionID string            `json:"submission_id,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// RayJobStatus is the response body from the Ray Jobs API's job status
+// endpoint.
+type RayJobStatus struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// Terminal states a Ray job can end up in, per the Ray Jobs API.
+const (
+	rayJobSucceeded = "SUCCEEDED"
+	rayJobFailed    = "FAILED"
+	rayJobStopped   = "STOPPED"
+)
+
+// rayJobTerminal reports whether status is one Ray will never transition out
+// of on its own.
+func rayJobTerminal(status string) bool {
+	switch status {
+	case rayJobSucceeded, rayJobFailed, rayJobStopped:
+		return true
+	default:
+		return false
+	}
+}
+
+// submitRayJob posts submission to the Ray Jobs API of the cluster behind
+// uid, through the server's own proxy, and returns the assigned submission
+// id.
+func submitRayJob(uid string, submission RayJobSubmission) (string, error) {
+	body, err := json.Marshal(submission)
+	if err != nil {
+		return "", fmt.Errorf("encode job submission: %w", err)
+	}
+
+	url := fmt.Sprintf("http://localhost:8080/proxy/%s/api/jobs/", uid)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("submit job: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("submit job: server returned %s: %s", resp.Status, data)
+	}
+
+	var decoded struct {
+		SubmissionID string `json:"submission_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return "", fmt.Errorf("decode submit response: %w", err)
+	}
+	return decoded.SubmissionID, nil
+}
+
+// rayJobStatus fetches the current status of submissionID on the cluster
+// behind uid.
+func rayJobStatus(uid, submissionID string) (RayJobStatus, error) {
+	url := fmt.Sprintf("http://localhost:8080/proxy/%s/api/jobs/%s", uid, submissionID)
+	resp, err := http.Get(url)
+	if err != nil {
+		return RayJobStatus{}, fmt.Errorf("get job status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var decoded RayJobStatus
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return RayJobStatus{}, fmt.Errorf("decode job status: %w", err)
+	}
+	return decoded, nil
+}
+
+// rayJobLogs fetches the full log text captured for submissionID so far.
+func rayJobLogs(uid, submissionID string) (string, error) {
+	url := fmt.Sprintf("http://localhost:8080/proxy/%s/api/jobs/%s/logs", uid, submissionID)
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("get job logs: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var decoded struct {
+		Logs string `json:"logs"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return "", fmt.Errorf("decode job logs: %w", err)
+	}
+	return decoded.Logs, nil
+}
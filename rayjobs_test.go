@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestRayJobTerminal(t *testing.T) {
+	cases := []struct {
+		status string
+		want   bool
+	}{
+		{rayJobSucceeded, true},
+		{rayJobFailed, true},
+		{rayJobStopped, true},
+		{"PENDING", false},
+		{"RUNNING", false},
+		{"", false},
+	}
+
+	for _, tc := range cases {
+		if got := rayJobTerminal(tc.status); got != tc.want {
+			t.Errorf("rayJobTerminal(%q) = %v, want %v", tc.status, got, tc.want)
+		}
+	}
+}
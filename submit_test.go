@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestShellQuoteJoin(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "simple words",
+			args: []string{"python3", "run.py"},
+			want: `'python3' 'run.py'`,
+		},
+		{
+			name: "argument with spaces stays one word",
+			args: []string{"python3", "run.py", "hello world"},
+			want: `'python3' 'run.py' 'hello world'`,
+		},
+		{
+			name: "embedded single quote is escaped",
+			args: []string{"echo", "it's here"},
+			want: `'echo' 'it'"'"'s here'`,
+		},
+		{
+			name: "no args",
+			args: nil,
+			want: "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := shellQuoteJoin(tc.args); got != tc.want {
+				t.Errorf("shellQuoteJoin(%v) = %q, want %q", tc.args, got, tc.want)
+			}
+		})
+	}
+}